@@ -0,0 +1,131 @@
+package oauth2
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type DeviceAuthorizationResponse struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete,omitempty"`
+	ExpiresIn               int64  `json:"expires_in"`
+	Interval                int64  `json:"interval"`
+}
+
+func RequestDeviceAuthorization(
+	ctx context.Context,
+	cconfig ClientConfig,
+	sconfig ServerConfig,
+	hc *http.Client,
+) (request Request, response DeviceAuthorizationResponse, err error) {
+	var (
+		req  *http.Request
+		resp *http.Response
+		body []byte
+	)
+
+	request.Form = url.Values{
+		"client_id": {cconfig.ClientID},
+	}
+
+	switch cconfig.AuthMethod {
+	case ClientSecretPostAuthMethod:
+		request.Form.Set("client_secret", cconfig.ClientSecret)
+	}
+
+	if req, err = http.NewRequestWithContext(
+		ctx,
+		http.MethodPost,
+		sconfig.DeviceAuthorizationEndpoint,
+		strings.NewReader(request.Form.Encode()),
+	); err != nil {
+		return request, response, err
+	}
+
+	if cconfig.AuthMethod == ClientSecretBasicAuthMethod {
+		req.SetBasicAuth(cconfig.ClientID, cconfig.ClientSecret)
+	}
+
+	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+
+	request.Method = req.Method
+	request.Headers = req.Header
+	request.URL = req.URL
+
+	if resp, err = hc.Do(req); err != nil {
+		return request, response, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return request, response, ParseError(resp)
+	}
+
+	if body, err = io.ReadAll(resp.Body); err != nil {
+		return request, response, errors.Wrapf(err, "failed to read device authorization response body")
+	}
+
+	if err = json.Unmarshal(body, &response); err != nil {
+		return request, response, errors.Wrapf(err, "failed to parse device authorization response")
+	}
+
+	if response.Interval == 0 {
+		response.Interval = 5
+	}
+
+	return request, response, nil
+}
+
+// PollDeviceToken repeatedly calls the token endpoint with the device_code
+// grant until the user has completed (or abandoned) the verification step,
+// honoring the polling interval negotiated with RequestDeviceAuthorization.
+func PollDeviceToken(
+	ctx context.Context,
+	cconfig ClientConfig,
+	sconfig ServerConfig,
+	hc *http.Client,
+	deviceCode string,
+	interval int64,
+) (request Request, response TokenResponse, err error) {
+	cconfig.GrantType = DeviceGrantType
+
+	if interval <= 0 {
+		interval = 5
+	}
+
+	for {
+		request, response, err = RequestToken(ctx, cconfig, sconfig, hc, WithDeviceCode(deviceCode))
+		if err == nil {
+			return request, response, nil
+		}
+
+		oerr, ok := err.(*Error)
+		if !ok {
+			return request, response, err
+		}
+
+		switch oerr.ErrorCode {
+		case "authorization_pending":
+		case "slow_down":
+			interval += 5
+		default:
+			return request, response, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return request, response, ctx.Err()
+		case <-time.After(time.Duration(interval) * time.Second):
+		}
+	}
+}
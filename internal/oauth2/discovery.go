@@ -0,0 +1,164 @@
+package oauth2
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// well-known paths to probe, in order, relative to the issuer URL.
+var wellKnownPaths = []string{
+	"/.well-known/openid-configuration",
+	"/.well-known/oauth-authorization-server",
+}
+
+type MTLSEndpointAliases struct {
+	TokenEndpoint                      string `json:"token_endpoint,omitempty"`
+	DeviceAuthorizationEndpoint        string `json:"device_authorization_endpoint,omitempty"`
+	PushedAuthorizationRequestEndpoint string `json:"pushed_authorization_request_endpoint,omitempty"`
+	IntrospectionEndpoint              string `json:"introspection_endpoint,omitempty"`
+	RevocationEndpoint                 string `json:"revocation_endpoint,omitempty"`
+}
+
+type serverMetadata struct {
+	Issuer                             string               `json:"issuer"`
+	AuthorizationEndpoint              string               `json:"authorization_endpoint"`
+	TokenEndpoint                      string               `json:"token_endpoint"`
+	DeviceAuthorizationEndpoint        string               `json:"device_authorization_endpoint"`
+	JWKSURI                            string               `json:"jwks_uri"`
+	MTLSEndpointAliases                *MTLSEndpointAliases `json:"mtls_endpoint_aliases,omitempty"`
+	PushedAuthorizationRequestEndpoint string               `json:"pushed_authorization_request_endpoint"`
+	IntrospectionEndpoint              string               `json:"introspection_endpoint"`
+	RevocationEndpoint                 string               `json:"revocation_endpoint"`
+	TokenEndpointAuthMethodsSupported  []string             `json:"token_endpoint_auth_methods_supported"`
+	GrantTypesSupported                []string             `json:"grant_types_supported"`
+	CodeChallengeMethodsSupported      []string             `json:"code_challenge_methods_supported"`
+	ResponseModesSupported             []string             `json:"response_modes_supported"`
+	IDTokenSigningAlgValuesSupported   []string             `json:"id_token_signing_alg_values_supported"`
+}
+
+var discoveryCache = struct {
+	mu      sync.Mutex
+	entries map[string]discoveryCacheEntry
+}{entries: map[string]discoveryCacheEntry{}}
+
+type discoveryCacheEntry struct {
+	sconfig ServerConfig
+	expires time.Time
+}
+
+// DiscoverServer fetches server metadata from the issuer's well-known
+// discovery document, trying the OpenID Connect location first and falling
+// back to the plain OAuth 2.0 Authorization Server Metadata location from
+// RFC 8414. The result is cached according to the response's Expires /
+// Cache-Control headers.
+func DiscoverServer(ctx context.Context, issuerURL string, hc *http.Client) (sconfig ServerConfig, err error) {
+	discoveryCache.mu.Lock()
+	if entry, ok := discoveryCache.entries[issuerURL]; ok && time.Now().Before(entry.expires) {
+		discoveryCache.mu.Unlock()
+		return entry.sconfig, nil
+	}
+	discoveryCache.mu.Unlock()
+
+	var expires time.Time
+
+	for _, path := range wellKnownPaths {
+		if sconfig, expires, err = fetchServerMetadata(ctx, issuerURL, path, hc); err == nil {
+			break
+		}
+	}
+
+	if err != nil {
+		return sconfig, errors.Wrapf(err, "failed to discover server metadata for %q", issuerURL)
+	}
+
+	discoveryCache.mu.Lock()
+	discoveryCache.entries[issuerURL] = discoveryCacheEntry{sconfig: sconfig, expires: expires}
+	discoveryCache.mu.Unlock()
+
+	return sconfig, nil
+}
+
+func fetchServerMetadata(ctx context.Context, issuerURL string, path string, hc *http.Client) (ServerConfig, time.Time, error) {
+	var (
+		req      *http.Request
+		resp     *http.Response
+		body     []byte
+		metadata serverMetadata
+		err      error
+	)
+
+	if req, err = http.NewRequestWithContext(
+		ctx, http.MethodGet, strings.TrimSuffix(issuerURL, "/")+path, nil,
+	); err != nil {
+		return ServerConfig{}, time.Time{}, err
+	}
+
+	if resp, err = hc.Do(req); err != nil {
+		return ServerConfig{}, time.Time{}, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return ServerConfig{}, time.Time{}, ParseError(resp)
+	}
+
+	if body, err = io.ReadAll(resp.Body); err != nil {
+		return ServerConfig{}, time.Time{}, errors.Wrapf(err, "failed to read discovery response body")
+	}
+
+	if err = json.Unmarshal(body, &metadata); err != nil {
+		return ServerConfig{}, time.Time{}, errors.Wrapf(err, "failed to parse discovery response")
+	}
+
+	if strings.TrimSuffix(metadata.Issuer, "/") != strings.TrimSuffix(issuerURL, "/") {
+		return ServerConfig{}, time.Time{}, errors.Errorf(
+			"issuer mismatch: requested %q, server returned %q", issuerURL, metadata.Issuer,
+		)
+	}
+
+	return ServerConfig{
+		AuthorizationEndpoint:              metadata.AuthorizationEndpoint,
+		TokenEndpoint:                      metadata.TokenEndpoint,
+		DeviceAuthorizationEndpoint:        metadata.DeviceAuthorizationEndpoint,
+		JWKSURI:                            metadata.JWKSURI,
+		MTLSEndpointAliases:                metadata.MTLSEndpointAliases,
+		PushedAuthorizationRequestEndpoint: metadata.PushedAuthorizationRequestEndpoint,
+		IntrospectionEndpoint:              metadata.IntrospectionEndpoint,
+		RevocationEndpoint:                 metadata.RevocationEndpoint,
+		TokenEndpointAuthMethodsSupported:  metadata.TokenEndpointAuthMethodsSupported,
+		GrantTypesSupported:                metadata.GrantTypesSupported,
+		CodeChallengeMethodsSupported:      metadata.CodeChallengeMethodsSupported,
+		ResponseModesSupported:             metadata.ResponseModesSupported,
+		IDTokenSigningAlgValuesSupported:   metadata.IDTokenSigningAlgValuesSupported,
+	}, cacheExpiry(resp), nil
+}
+
+func cacheExpiry(resp *http.Response) time.Time {
+	if cc := resp.Header.Get("Cache-Control"); cc != "" {
+		for _, directive := range strings.Split(cc, ",") {
+			directive = strings.TrimSpace(directive)
+			if strings.HasPrefix(directive, "max-age=") {
+				if seconds, err := strconv.Atoi(strings.TrimPrefix(directive, "max-age=")); err == nil {
+					return time.Now().Add(time.Duration(seconds) * time.Second)
+				}
+			}
+		}
+	}
+
+	if expires := resp.Header.Get("Expires"); expires != "" {
+		if t, err := http.ParseTime(expires); err == nil {
+			return t
+		}
+	}
+
+	return time.Now().Add(5 * time.Minute)
+}
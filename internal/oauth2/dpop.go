@@ -0,0 +1,166 @@
+package oauth2
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/lithammer/shortuuid/v4"
+	"github.com/pkg/errors"
+)
+
+const DPoPJwtTyp = "dpop+jwt"
+
+// DPoPSigner holds the ephemeral key pair a client uses to prove possession
+// of an access token, per RFC 9449.
+type DPoPSigner struct {
+	key interface{}
+	jwk jose.JSONWebKey
+}
+
+// NewDPoPSigner generates a fresh ES256 key pair to bind a DPoP proof to.
+func NewDPoPSigner() (*DPoPSigner, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to generate DPoP key")
+	}
+
+	return &DPoPSigner{
+		key: key,
+		jwk: jose.JSONWebKey{Key: &key.PublicKey, Algorithm: string(jose.ES256)},
+	}, nil
+}
+
+// Thumbprint returns the base64url-encoded SHA-256 JWK thumbprint of the
+// signer's public key, used to bind an access token to this key.
+func (s *DPoPSigner) Thumbprint() (string, error) {
+	sum, err := s.jwk.Thumbprint(crypto.SHA256)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to compute DPoP key thumbprint")
+	}
+
+	return base64.RawURLEncoding.EncodeToString(sum), nil
+}
+
+// Proof builds a signed DPoP JWT for the given HTTP method and URL. When
+// accessToken is non-empty the proof is bound to it via the `ath` claim;
+// when nonce is non-empty it is echoed back per the server's DPoP-Nonce
+// challenge.
+func (s *DPoPSigner) Proof(htm string, htu string, accessToken string, nonce string) (string, error) {
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.ES256, Key: s.key}, &jose.SignerOptions{
+		ExtraHeaders: map[jose.HeaderKey]interface{}{
+			"typ": DPoPJwtTyp,
+			"jwk": s.jwk,
+		},
+	})
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to create DPoP signer")
+	}
+
+	claims := map[string]interface{}{
+		"jti": shortuuid.New(),
+		"htm": htm,
+		"htu": htu,
+		"iat": time.Now().Unix(),
+	}
+
+	if accessToken != "" {
+		sum := sha256.Sum256([]byte(accessToken))
+		claims["ath"] = base64.RawURLEncoding.EncodeToString(sum[:])
+	}
+
+	if nonce != "" {
+		claims["nonce"] = nonce
+	}
+
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to marshal DPoP claims")
+	}
+
+	obj, err := signer.Sign(payload)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to sign DPoP proof")
+	}
+
+	return obj.CompactSerialize()
+}
+
+// dpopRoundTripper attaches a DPoP proof, bound to token, to every outbound
+// request and re-signs once with the server-provided nonce on a 401
+// response that carries a DPoP-Nonce challenge.
+type dpopRoundTripper struct {
+	next   http.RoundTripper
+	signer *DPoPSigner
+	token  string
+}
+
+// NewDPoPRoundTripper wraps next (or http.DefaultTransport when nil) so that
+// every request sent through the resulting *http.Client carries a DPoP
+// proof bound to token.
+func NewDPoPRoundTripper(signer *DPoPSigner, token string, next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	return &dpopRoundTripper{next: next, signer: signer, token: token}
+}
+
+func (rt *dpopRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	proof, err := rt.signer.Proof(req.Method, requestURL(req), rt.token, "")
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("DPoP", proof)
+
+	resp, err := rt.next.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+
+	nonce := resp.Header.Get("DPoP-Nonce")
+	if resp.StatusCode != http.StatusUnauthorized || nonce == "" {
+		return resp, nil
+	}
+
+	// A request body can only be replayed if it can be re-obtained; bail out
+	// and hand back the original response rather than retrying with an
+	// empty body.
+	if req.Body != nil && req.GetBody == nil {
+		return resp, nil
+	}
+
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	if req.GetBody != nil {
+		if req.Body, err = req.GetBody(); err != nil {
+			return resp, err
+		}
+	}
+
+	if proof, err = rt.signer.Proof(req.Method, requestURL(req), rt.token, nonce); err != nil {
+		return resp, err
+	}
+
+	req.Header.Set("DPoP", proof)
+
+	return rt.next.RoundTrip(req)
+}
+
+func requestURL(req *http.Request) string {
+	u := *req.URL
+	u.RawQuery = ""
+	u.Fragment = ""
+
+	return u.String()
+}
@@ -0,0 +1,41 @@
+package oauth2
+
+// token type identifiers, see RFC 8693 section 3.
+const (
+	AccessTokenType  string = "urn:ietf:params:oauth:token-type:access_token"
+	RefreshTokenType string = "urn:ietf:params:oauth:token-type:refresh_token"
+	IDTokenType      string = "urn:ietf:params:oauth:token-type:id_token"
+	JWTTokenType     string = "urn:ietf:params:oauth:token-type:jwt"
+)
+
+func WithSubjectToken(token string, tokenType string) func(*RequestTokenParams) {
+	return func(opts *RequestTokenParams) {
+		opts.SubjectToken = token
+		opts.SubjectTokenType = tokenType
+	}
+}
+
+func WithActorToken(token string, tokenType string) func(*RequestTokenParams) {
+	return func(opts *RequestTokenParams) {
+		opts.ActorToken = token
+		opts.ActorTokenType = tokenType
+	}
+}
+
+func WithAudience(audience string) func(*RequestTokenParams) {
+	return func(opts *RequestTokenParams) {
+		opts.Audience = audience
+	}
+}
+
+func WithResource(resource string) func(*RequestTokenParams) {
+	return func(opts *RequestTokenParams) {
+		opts.Resource = resource
+	}
+}
+
+func WithRequestedTokenType(tokenType string) func(*RequestTokenParams) {
+	return func(opts *RequestTokenParams) {
+		opts.RequestedTokenType = tokenType
+	}
+}
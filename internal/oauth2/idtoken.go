@@ -0,0 +1,351 @@
+package oauth2
+
+import (
+	"context"
+	"crypto"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/go-jose/go-jose/v3/jwt"
+	"github.com/pkg/errors"
+)
+
+const jwksMinRefreshInterval = 60 * time.Second
+
+// IDTokenClaims holds the standard OIDC ID token claims plus any
+// non-standard ones under Raw.
+type IDTokenClaims struct {
+	Issuer          string
+	Subject         string
+	Audience        []string
+	Expiry          int64
+	IssuedAt        int64
+	NotBefore       int64
+	Nonce           string
+	AuthorizedParty string
+	AtHash          string
+	CHash           string
+	Raw             map[string]interface{}
+}
+
+type IDTokenVerificationParams struct {
+	Nonce       string
+	AccessToken string
+	Code        string
+	Leeway      time.Duration
+}
+
+type IDTokenVerificationOption func(*IDTokenVerificationParams)
+
+func WithNonce(nonce string) IDTokenVerificationOption {
+	return func(opts *IDTokenVerificationParams) {
+		opts.Nonce = nonce
+	}
+}
+
+func WithAccessTokenHash(accessToken string) IDTokenVerificationOption {
+	return func(opts *IDTokenVerificationParams) {
+		opts.AccessToken = accessToken
+	}
+}
+
+func WithCodeHash(code string) IDTokenVerificationOption {
+	return func(opts *IDTokenVerificationParams) {
+		opts.Code = code
+	}
+}
+
+func WithLeeway(leeway time.Duration) IDTokenVerificationOption {
+	return func(opts *IDTokenVerificationParams) {
+		opts.Leeway = leeway
+	}
+}
+
+// VerifyIDToken parses and validates idToken against cconfig and sconfig:
+// signature (via sconfig.JWKSURI, with key caching and rotation), issuer,
+// audience, azp, exp/iat/nbf (with leeway), nonce, and optionally at_hash /
+// c_hash.
+func VerifyIDToken(
+	ctx context.Context,
+	idToken string,
+	cconfig ClientConfig,
+	sconfig ServerConfig,
+	hc *http.Client,
+	opts ...IDTokenVerificationOption,
+) (*IDTokenClaims, error) {
+	params := IDTokenVerificationParams{Leeway: 60 * time.Second}
+
+	for _, opt := range opts {
+		opt(&params)
+	}
+
+	token, err := jwt.ParseSigned(idToken)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse id_token")
+	}
+
+	if len(token.Headers) == 0 {
+		return nil, errors.New("id_token has no headers")
+	}
+
+	header := token.Headers[0]
+
+	if len(sconfig.IDTokenSigningAlgValuesSupported) > 0 && !contains(sconfig.IDTokenSigningAlgValuesSupported, header.Algorithm) {
+		return nil, errors.Errorf("id_token alg %q is not advertised in id_token_signing_alg_values_supported", header.Algorithm)
+	}
+
+	key, err := idTokenJWKSCache(sconfig.JWKSURI).key(ctx, hc, sconfig.JWKSURI, header.KeyID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to resolve id_token signing key")
+	}
+
+	raw := map[string]interface{}{}
+
+	if err = token.Claims(key.Key, &raw); err != nil {
+		return nil, errors.Wrapf(err, "failed to verify id_token signature")
+	}
+
+	claims := idTokenClaimsFromRaw(raw)
+	claims.Raw = raw
+
+	now := time.Now()
+
+	if claims.Issuer != cconfig.IssuerURL {
+		return nil, errors.Errorf("id_token iss %q does not match issuer %q", claims.Issuer, cconfig.IssuerURL)
+	}
+
+	if !contains(claims.Audience, cconfig.ClientID) {
+		return nil, errors.Errorf("id_token aud does not contain client_id %q", cconfig.ClientID)
+	}
+
+	if len(claims.Audience) > 1 && claims.AuthorizedParty != cconfig.ClientID {
+		return nil, errors.Errorf("id_token azp %q does not match client_id %q", claims.AuthorizedParty, cconfig.ClientID)
+	}
+
+	if now.After(time.Unix(claims.Expiry, 0).Add(params.Leeway)) {
+		return nil, errors.New("id_token has expired")
+	}
+
+	if claims.IssuedAt > 0 && now.Add(params.Leeway).Before(time.Unix(claims.IssuedAt, 0)) {
+		return nil, errors.New("id_token iat is in the future")
+	}
+
+	if claims.NotBefore > 0 && now.Add(params.Leeway).Before(time.Unix(claims.NotBefore, 0)) {
+		return nil, errors.New("id_token nbf is in the future")
+	}
+
+	if params.Nonce != "" && claims.Nonce != params.Nonce {
+		return nil, errors.New("id_token nonce does not match the authorization request")
+	}
+
+	alg := jose.SignatureAlgorithm(header.Algorithm)
+
+	if params.AccessToken != "" && claims.AtHash != "" {
+		if err = verifyHalfHash(alg, params.AccessToken, claims.AtHash); err != nil {
+			return nil, errors.Wrapf(err, "id_token at_hash mismatch")
+		}
+	}
+
+	if params.Code != "" && claims.CHash != "" {
+		if err = verifyHalfHash(alg, params.Code, claims.CHash); err != nil {
+			return nil, errors.Wrapf(err, "id_token c_hash mismatch")
+		}
+	}
+
+	return claims, nil
+}
+
+func idTokenClaimsFromRaw(raw map[string]interface{}) *IDTokenClaims {
+	claims := &IDTokenClaims{
+		Issuer:          stringClaim(raw, "iss"),
+		Subject:         stringClaim(raw, "sub"),
+		Audience:        audienceClaim(raw),
+		Expiry:          int64Claim(raw, "exp"),
+		IssuedAt:        int64Claim(raw, "iat"),
+		NotBefore:       int64Claim(raw, "nbf"),
+		Nonce:           stringClaim(raw, "nonce"),
+		AuthorizedParty: stringClaim(raw, "azp"),
+		AtHash:          stringClaim(raw, "at_hash"),
+		CHash:           stringClaim(raw, "c_hash"),
+	}
+
+	return claims
+}
+
+func stringClaim(raw map[string]interface{}, key string) string {
+	v, _ := raw[key].(string)
+	return v
+}
+
+func int64Claim(raw map[string]interface{}, key string) int64 {
+	switch v := raw[key].(type) {
+	case float64:
+		return int64(v)
+	case int64:
+		return v
+	default:
+		return 0
+	}
+}
+
+func audienceClaim(raw map[string]interface{}) []string {
+	switch v := raw["aud"].(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		aud := make([]string, 0, len(v))
+
+		for _, a := range v {
+			if s, ok := a.(string); ok {
+				aud = append(aud, s)
+			}
+		}
+
+		return aud
+	default:
+		return nil
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// verifyHalfHash validates an at_hash/c_hash claim per OIDC Core section
+// 3.1.3.6: the hash algorithm matching alg is applied to value, and the
+// first half of the digest, base64url-encoded, must equal hash.
+func verifyHalfHash(alg jose.SignatureAlgorithm, value string, hash string) error {
+	h := hashForAlg(alg)
+	if h == 0 {
+		return errors.Errorf("unsupported id_token signing algorithm %q", alg)
+	}
+
+	digest := h.New()
+	digest.Write([]byte(value))
+	sum := digest.Sum(nil)
+
+	expected := base64.RawURLEncoding.EncodeToString(sum[:len(sum)/2])
+	if expected != hash {
+		return errors.New("hash does not match")
+	}
+
+	return nil
+}
+
+func hashForAlg(alg jose.SignatureAlgorithm) crypto.Hash {
+	switch alg {
+	case jose.RS256, jose.ES256, jose.PS256, jose.HS256:
+		return crypto.SHA256
+	case jose.RS384, jose.ES384, jose.PS384, jose.HS384:
+		return crypto.SHA384
+	case jose.RS512, jose.ES512, jose.PS512, jose.HS512:
+		return crypto.SHA512
+	default:
+		return 0
+	}
+}
+
+type jwksCache struct {
+	mu        sync.Mutex
+	keys      map[string]jose.JSONWebKey
+	fetchedAt time.Time
+}
+
+var jwksCaches = struct {
+	mu    sync.Mutex
+	byURI map[string]*jwksCache
+}{byURI: map[string]*jwksCache{}}
+
+func idTokenJWKSCache(uri string) *jwksCache {
+	jwksCaches.mu.Lock()
+	defer jwksCaches.mu.Unlock()
+
+	c, ok := jwksCaches.byURI[uri]
+	if !ok {
+		c = &jwksCache{keys: map[string]jose.JSONWebKey{}}
+		jwksCaches.byURI[uri] = c
+	}
+
+	return c
+}
+
+func (c *jwksCache) key(ctx context.Context, hc *http.Client, uri string, kid string) (jose.JSONWebKey, error) {
+	c.mu.Lock()
+	key, ok := c.keys[kid]
+	rateLimited := time.Since(c.fetchedAt) < jwksMinRefreshInterval
+	c.mu.Unlock()
+
+	if ok {
+		return key, nil
+	}
+
+	if rateLimited {
+		return key, errors.Errorf("key %q not found and jwks refresh is rate-limited", kid)
+	}
+
+	if err := c.refresh(ctx, hc, uri); err != nil {
+		return key, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key, ok = c.keys[kid]
+	if !ok {
+		return key, errors.Errorf("key %q not found after jwks refresh", kid)
+	}
+
+	return key, nil
+}
+
+func (c *jwksCache) refresh(ctx context.Context, hc *http.Client, uri string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+	if err != nil {
+		return errors.Wrapf(err, "failed to build jwks request")
+	}
+
+	resp, err := hc.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "failed to fetch jwks")
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return ParseError(resp)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read jwks response body")
+	}
+
+	var jwks jose.JSONWebKeySet
+
+	if err = json.Unmarshal(body, &jwks); err != nil {
+		return errors.Wrapf(err, "failed to parse jwks response")
+	}
+
+	keys := make(map[string]jose.JSONWebKey, len(jwks.Keys))
+	for _, k := range jwks.Keys {
+		keys[k.KeyID] = k
+	}
+
+	c.mu.Lock()
+	c.keys = keys
+	c.fetchedAt = time.Now()
+	c.mu.Unlock()
+
+	return nil
+}
@@ -19,13 +19,13 @@ import (
 const (
 	AuthorizationCodeGrantType string = "authorization_code"
 	ClientCredentialsGrantType string = "client_credentials"
+	DeviceGrantType            string = "urn:ietf:params:oauth:grant-type:device_code"
+	TokenExchangeGrantType     string = "urn:ietf:params:oauth:grant-type:token-exchange"
 	// ImplicitGrantType          string = "implicit"
 	// RefreshTokenGrantType      string = "refresh_token"
 	// PasswordGrantType          string = "password"
 	// JWTBearerGrantType         string = "urn:ietf:params:oauth:grant-type:jwt-bearer"
 	// CIBAGrantType              string = "urn:openid:params:grant-type:ciba"
-	// TokenExchangeGrantType     string = "urn:ietf:params:oauth:grant-type:token-exchange"
-	// DeviceGrantType            string = "urn:ietf:params:oauth:grant-type:device_code"
 )
 
 // auth methods
@@ -47,23 +47,98 @@ type ClientConfig struct {
 	AuthMethod   string
 }
 
-func RequestAuthorization(addr string, cconfig ClientConfig, sconfig ServerConfig) (r Request, err error) {
+type ServerConfig struct {
+	AuthorizationEndpoint              string
+	TokenEndpoint                      string
+	DeviceAuthorizationEndpoint        string
+	JWKSURI                            string
+	MTLSEndpointAliases                *MTLSEndpointAliases
+	PushedAuthorizationRequestEndpoint string
+	IntrospectionEndpoint              string
+	RevocationEndpoint                 string
+	TokenEndpointAuthMethodsSupported  []string
+	GrantTypesSupported                []string
+	CodeChallengeMethodsSupported      []string
+	ResponseModesSupported             []string
+	IDTokenSigningAlgValuesSupported   []string
+}
+
+type AuthorizationRequestParams struct {
+	PKCE       bool
+	PKCEMethod string
+	RequestURI string
+}
+
+type AuthorizationRequestOption func(*AuthorizationRequestParams)
+
+func WithPKCE() AuthorizationRequestOption {
+	return func(opts *AuthorizationRequestParams) {
+		opts.PKCE = true
+	}
+}
+
+func WithPKCEMethod(method string) AuthorizationRequestOption {
+	return func(opts *AuthorizationRequestParams) {
+		opts.PKCE = true
+		opts.PKCEMethod = method
+	}
+}
+
+func RequestAuthorization(
+	addr string,
+	cconfig ClientConfig,
+	sconfig ServerConfig,
+	opts ...AuthorizationRequestOption,
+) (r Request, verifier string, nonce string, state string, err error) {
+	var params AuthorizationRequestParams
+
+	for _, opt := range opts {
+		opt(&params)
+	}
+
 	if r.URL, err = url.Parse(sconfig.AuthorizationEndpoint); err != nil {
-		return r, errors.Wrapf(err, "failed to parse authorization endpoint")
+		return r, verifier, nonce, state, errors.Wrapf(err, "failed to parse authorization endpoint")
+	}
+
+	if params.RequestURI != "" {
+		r.URL.RawQuery = url.Values{
+			"client_id":   {cconfig.ClientID},
+			"request_uri": {params.RequestURI},
+		}.Encode()
+		r.Method = http.MethodGet
+
+		return r, verifier, nonce, state, nil
 	}
 
+	nonce = shortuuid.New()
+	state = shortuuid.New()
+
 	values := url.Values{
 		"client_id":     {cconfig.ClientID},
 		"response_type": {"code"},
 		"redirect_uri":  {"http://" + addr + "/callback"},
-		"state":         {shortuuid.New()},
-		"nonce":         {shortuuid.New()},
+		"state":         {state},
+		"nonce":         {nonce},
+	}
+
+	if params.PKCE {
+		method := params.PKCEMethod
+		if method == "" {
+			method = PKCEMethodS256
+		}
+
+		if verifier, err = GenerateCodeVerifier(); err != nil {
+			return r, verifier, nonce, state, errors.Wrapf(err, "failed to generate PKCE code verifier")
+		}
+
+		values.Set("code_challenge", CodeChallenge(verifier, method))
+		values.Set("code_challenge_method", method)
 	}
 
 	r.URL.RawQuery = values.Encode()
 	r.Method = http.MethodGet
 
-	return r, nil
+	return r, verifier, nonce, state, nil
 }
 
 func WaitForCallback(addr string) (request Request, err error) {
@@ -110,18 +185,31 @@ func WaitForCallback(addr string) (request Request, err error) {
 }
 
 type TokenResponse struct {
-	AccessToken     string `json:"access_token,omitempty"`
-	ExpiresIn       int64  `json:"expires_in,omitempty"`
-	IDToken         string `json:"id_token,omitempty"`
-	IssuedTokenType string `json:"issued_token_type,omitempty"`
-	RefreshToken    string `json:"refresh_token,omitempty"`
-	Scope           string `json:"scope,omitempty"`
-	TokenType       string `json:"token_type,omitempty"`
+	AccessToken       string `json:"access_token,omitempty"`
+	ExpiresIn         int64  `json:"expires_in,omitempty"`
+	IDToken           string `json:"id_token,omitempty"`
+	IssuedTokenType   string `json:"issued_token_type,omitempty"`
+	RefreshToken      string `json:"refresh_token,omitempty"`
+	Scope             string `json:"scope,omitempty"`
+	TokenType         string `json:"token_type,omitempty"`
+	DPoPJWKThumbprint string `json:"-"`
 }
 
 type RequestTokenParams struct {
-	Code        string
-	RedirectURL string
+	Code         string
+	RedirectURL  string
+	DeviceCode   string
+	CodeVerifier string
+	DPoP         *DPoPSigner
+	Scope        string
+
+	SubjectToken       string
+	SubjectTokenType   string
+	ActorToken         string
+	ActorTokenType     string
+	Audience           string
+	Resource           string
+	RequestedTokenType string
 }
 
 type RequestTokenOption func(*RequestTokenParams)
@@ -138,6 +226,36 @@ func WithRedirectURL(url string) func(*RequestTokenParams) {
 	}
 }
 
+func WithDeviceCode(code string) func(*RequestTokenParams) {
+	return func(opts *RequestTokenParams) {
+		opts.DeviceCode = code
+	}
+}
+
+// WithCodeVerifier completes the PKCE exchange started by
+// RequestAuthorization's WithPKCE/WithPKCEMethod by sending code_verifier to
+// the token endpoint. It is named WithCodeVerifier rather than WithPKCE,
+// since Go does not allow two top-level functions of that name with
+// different option types; RFC 7636 also has no code_challenge_method
+// equivalent to send here, so there is no WithPKCEMethod on this side.
+func WithCodeVerifier(verifier string) func(*RequestTokenParams) {
+	return func(opts *RequestTokenParams) {
+		opts.CodeVerifier = verifier
+	}
+}
+
+func WithDPoP(signer *DPoPSigner) func(*RequestTokenParams) {
+	return func(opts *RequestTokenParams) {
+		opts.DPoP = signer
+	}
+}
+
+func WithScope(scope string) func(*RequestTokenParams) {
+	return func(opts *RequestTokenParams) {
+		opts.Scope = scope
+	}
+}
+
 func RequestToken(
 	ctx context.Context,
 	cconfig ClientConfig,
@@ -174,6 +292,40 @@ func RequestToken(
 		request.Form.Set("code", params.Code)
 	}
 
+	if params.DeviceCode != "" {
+		request.Form.Set("device_code", params.DeviceCode)
+	}
+
+	if params.CodeVerifier != "" {
+		request.Form.Set("code_verifier", params.CodeVerifier)
+	}
+
+	if params.Scope != "" {
+		request.Form.Set("scope", params.Scope)
+	}
+
+	if cconfig.GrantType == TokenExchangeGrantType {
+		request.Form.Set("subject_token", params.SubjectToken)
+		request.Form.Set("subject_token_type", params.SubjectTokenType)
+
+		if params.ActorToken != "" {
+			request.Form.Set("actor_token", params.ActorToken)
+			request.Form.Set("actor_token_type", params.ActorTokenType)
+		}
+
+		if params.Audience != "" {
+			request.Form.Set("audience", params.Audience)
+		}
+
+		if params.Resource != "" {
+			request.Form.Set("resource", params.Resource)
+		}
+
+		if params.RequestedTokenType != "" {
+			request.Form.Set("requested_token_type", params.RequestedTokenType)
+		}
+	}
+
 	if req, err = http.NewRequestWithContext(
 		ctx,
 		http.MethodPost,
@@ -189,6 +341,16 @@ func RequestToken(
 
 	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
 
+	if params.DPoP != nil {
+		var proof string
+
+		if proof, err = params.DPoP.Proof(req.Method, sconfig.TokenEndpoint, "", ""); err != nil {
+			return request, response, err
+		}
+
+		req.Header.Set("DPoP", proof)
+	}
+
 	request.Method = req.Method
 	request.Headers = req.Header
 	request.URL = req.URL
@@ -199,6 +361,40 @@ func RequestToken(
 
 	defer resp.Body.Close()
 
+	if params.DPoP != nil && resp.StatusCode == http.StatusBadRequest {
+		if nonce := resp.Header.Get("DPoP-Nonce"); nonce != "" {
+			resp.Body.Close()
+
+			var proof string
+
+			if proof, err = params.DPoP.Proof(req.Method, sconfig.TokenEndpoint, "", nonce); err != nil {
+				return request, response, err
+			}
+
+			if req, err = http.NewRequestWithContext(
+				ctx,
+				http.MethodPost,
+				sconfig.TokenEndpoint,
+				strings.NewReader(request.Form.Encode()),
+			); err != nil {
+				return request, response, err
+			}
+
+			req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+			req.Header.Set("DPoP", proof)
+
+			if cconfig.AuthMethod == ClientSecretBasicAuthMethod {
+				req.SetBasicAuth(cconfig.ClientID, cconfig.ClientSecret)
+			}
+
+			if resp, err = hc.Do(req); err != nil {
+				return request, response, err
+			}
+
+			defer resp.Body.Close()
+		}
+	}
+
 	if resp.StatusCode != http.StatusOK {
 		return request, response, ParseError(resp)
 	}
@@ -211,5 +407,11 @@ func RequestToken(
 		return request, response, fmt.Errorf("failed to parse exchange response: %w", err)
 	}
 
+	if params.DPoP != nil && strings.EqualFold(response.TokenType, "DPoP") {
+		if response.DPoPJWKThumbprint, err = params.DPoP.Thumbprint(); err != nil {
+			return request, response, err
+		}
+	}
+
 	return request, response, nil
 }
@@ -0,0 +1,95 @@
+package oauth2
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+type PushedAuthorizationResponse struct {
+	RequestURI string `json:"request_uri"`
+	ExpiresIn  int64  `json:"expires_in"`
+}
+
+// WithRequestURI makes RequestAuthorization emit a front-channel URL that
+// carries only client_id and request_uri, per RFC 9126 section 4. All other
+// authorization parameters are expected to have already been pushed to the
+// server via PushAuthorizationRequest.
+func WithRequestURI(requestURI string) AuthorizationRequestOption {
+	return func(opts *AuthorizationRequestParams) {
+		opts.RequestURI = requestURI
+	}
+}
+
+// PushAuthorizationRequest sends the authorization parameters to the
+// server's pushed authorization request endpoint and returns the resulting
+// request_uri for use with RequestAuthorization's WithRequestURI option.
+func PushAuthorizationRequest(
+	ctx context.Context,
+	cconfig ClientConfig,
+	sconfig ServerConfig,
+	hc *http.Client,
+	values url.Values,
+) (request Request, response PushedAuthorizationResponse, err error) {
+	var (
+		endpoint = sconfig.PushedAuthorizationRequestEndpoint
+		mtls     string
+		req      *http.Request
+		resp     *http.Response
+		body     []byte
+	)
+
+	if sconfig.MTLSEndpointAliases != nil {
+		mtls = sconfig.MTLSEndpointAliases.PushedAuthorizationRequestEndpoint
+	}
+
+	request.Form = values
+
+	if endpoint, err = request.AuthenticateClient(endpoint, mtls, cconfig, sconfig, hc); err != nil {
+		return request, response, err
+	}
+
+	if req, err = http.NewRequestWithContext(
+		ctx,
+		http.MethodPost,
+		endpoint,
+		strings.NewReader(request.Form.Encode()),
+	); err != nil {
+		return request, response, err
+	}
+
+	if cconfig.AuthMethod == ClientSecretBasicAuthMethod {
+		req.SetBasicAuth(cconfig.ClientID, cconfig.ClientSecret)
+	}
+
+	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+
+	request.Method = req.Method
+	request.Headers = req.Header
+	request.URL = req.URL
+
+	if resp, err = hc.Do(req); err != nil {
+		return request, response, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		return request, response, ParseError(resp)
+	}
+
+	if body, err = io.ReadAll(resp.Body); err != nil {
+		return request, response, errors.Wrapf(err, "failed to read pushed authorization response body")
+	}
+
+	if err = json.Unmarshal(body, &response); err != nil {
+		return request, response, errors.Wrapf(err, "failed to parse pushed authorization response")
+	}
+
+	return request, response, nil
+}
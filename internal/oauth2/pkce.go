@@ -0,0 +1,40 @@
+package oauth2
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+
+	"github.com/pkg/errors"
+)
+
+// PKCE transformation methods, see RFC 7636 section 4.2/4.3.
+const (
+	PKCEMethodPlain string = "plain"
+	PKCEMethodS256  string = "S256"
+)
+
+// GenerateCodeVerifier returns a cryptographically-random code_verifier of
+// the maximum length allowed by RFC 7636 (43-128 characters once base64url
+// encoded).
+func GenerateCodeVerifier() (string, error) {
+	b := make([]byte, 96)
+
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrapf(err, "failed to generate code verifier")
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// CodeChallenge derives a code_challenge from a code_verifier using the
+// given transformation method.
+func CodeChallenge(verifier string, method string) string {
+	if method == PKCEMethodPlain {
+		return verifier
+	}
+
+	sum := sha256.Sum256([]byte(verifier))
+
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}